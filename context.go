@@ -0,0 +1,65 @@
+package log
+
+import "context"
+
+// With returns a child logger that inherits this logger's prefix, handlers
+// and threshold, and merges kv (alternating key/value pairs, see AddInfoKV)
+// into the structured fields of every entry it emits from then on. The
+// child's in-memory buffer is isolated from the parent; call
+// WithSharedBuffer(true) on the result if Notes()/ToString() should reflect
+// entries from both.
+func (r *Log) With(kv ...any) *Log {
+	child := &Log{
+		Prefix:    r.Prefix,
+		ln:        make([]LogInfo, 0),
+		parentLn:  r.buf(),
+		osIsWin:   r.osIsWin,
+		formatter: r.formatter,
+		handlers:  r.handlers,
+		threshold: r.threshold,
+		fields:    mergeFields(r.fields, fieldsFromKV(kv)),
+		mu:        r.mu,
+	}
+	return child
+}
+
+// WithSharedBuffer returns a copy of this logger whose in-memory buffer
+// (Notes(), ToString(), HasErrors(), ...) either reads and appends to the
+// same backing slice as the logger it was derived from via With
+// (shared=true) or keeps its own (shared=false, the default for a logger
+// returned by With). Called on a logger that wasn't derived via With,
+// shared=true instead binds the copy to that logger's own buffer.
+func (r *Log) WithSharedBuffer(shared bool) *Log {
+	clone := *r
+	if shared {
+		if r.parentLn != nil {
+			clone.sharedLn = r.parentLn
+		} else {
+			clone.sharedLn = r.buf()
+		}
+	} else {
+		clone.sharedLn = nil
+		clone.ln = make([]LogInfo, 0)
+	}
+	return &clone
+}
+
+// contextKey is unexported so only this package can populate a context.Context
+// with a *Log under it
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying l, retrievable with FromContext.
+// This lets HTTP middleware or RPC handlers attach a request-scoped logger
+// (e.g. one tagged with a request id via With) for downstream code to use
+func NewContext(ctx context.Context, l *Log) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the *Log attached to ctx via NewContext, or a fresh
+// NewLog("") if none was attached
+func FromContext(ctx context.Context) *Log {
+	if l, ok := ctx.Value(contextKey{}).(*Log); ok {
+		return l
+	}
+	return NewLog("")
+}
@@ -0,0 +1,57 @@
+package log
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestJSONFormatterOutput verifies JSON renders one parseable object per
+// entry carrying the message, level and structured fields
+func TestJSONFormatterOutput(t *testing.T) {
+	l := NewLog("svc")
+	l.SetFormatter(JSON)
+	l.AddErrorKV("db write failed", "table", "users", "attempt", 2)
+
+	out := strings.TrimRight(l.ToString(), "\r\n")
+	var decoded struct {
+		Type    string         `json:"type"`
+		Message string         `json:"message"`
+		Fields  map[string]any `json:"fields"`
+	}
+	if err := json.Unmarshal([]byte(out), &decoded); err != nil {
+		t.Fatalf("JSON output did not parse: %v\noutput: %s", err, out)
+	}
+	if decoded.Type != string(Error) {
+		t.Errorf("Type = %q, want %q", decoded.Type, Error)
+	}
+	if decoded.Message != "db write failed" {
+		t.Errorf("Message = %q, want %q", decoded.Message, "db write failed")
+	}
+	if decoded.Fields["table"] != "users" {
+		t.Errorf("Fields[table] = %v, want %q", decoded.Fields["table"], "users")
+	}
+	if decoded.Fields["attempt"] != float64(2) {
+		t.Errorf("Fields[attempt] = %v, want 2", decoded.Fields["attempt"])
+	}
+}
+
+// TestAddInfoKVFieldMerging verifies AddInfoKV's alternating key/value pairs
+// land in Fields, and that a scoped logger's With fields are merged in too,
+// with the call-site fields winning on conflict
+func TestAddInfoKVFieldMerging(t *testing.T) {
+	l := NewLog("svc").With("request_id", "abc")
+	l.AddInfoKV("user signed in", "user", "alice", "request_id", "override")
+
+	notes := l.Notes()
+	if len(notes) != 1 {
+		t.Fatalf("got %d notes, want 1", len(notes))
+	}
+	fields := notes[0].Fields
+	if fields["user"] != "alice" {
+		t.Errorf("Fields[user] = %v, want %q", fields["user"], "alice")
+	}
+	if fields["request_id"] != "override" {
+		t.Errorf("Fields[request_id] = %v, want %q (call-site should win)", fields["request_id"], "override")
+	}
+}
@@ -0,0 +1,144 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Handler receives log entries as they are added to a Log, in addition to
+// the in-memory buffer kept by Notes(). Register one with Log.AddHandler.
+type Handler interface {
+	Handle(LogInfo) error
+}
+
+// writerHandler formats entries with a Formatter and writes them to w
+type writerHandler struct {
+	mu  sync.Mutex
+	w   io.Writer
+	fmt Formatter
+}
+
+// WriterHandler returns a Handler that formats entries with TEXT and writes
+// one per line to w. Use WriterHandlerWithFormatter to choose a different
+// Formatter, e.g. JSON for a log shipper.
+func WriterHandler(w io.Writer) Handler {
+	return WriterHandlerWithFormatter(w, TEXT)
+}
+
+// WriterHandlerWithFormatter is WriterHandler with an explicit Formatter
+func WriterHandlerWithFormatter(w io.Writer, f Formatter) Handler {
+	return &writerHandler{w: w, fmt: f}
+}
+
+func (h *writerHandler) Handle(li LogInfo) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := fmt.Fprintln(h.w, h.fmt.Format(li))
+	return err
+}
+
+// MultiHandler fans an entry out to every handler in hs, in order. It
+// returns the first error encountered, if any, after every handler has run
+func MultiHandler(hs ...Handler) Handler {
+	return &multiHandler{hs: hs}
+}
+
+type multiHandler struct {
+	hs []Handler
+}
+
+func (h *multiHandler) Handle(li LogInfo) error {
+	var first error
+	for _, sub := range h.hs {
+		if err := sub.Handle(li); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// FilterHandler wraps next so that only entries matching predicate reach it
+func FilterHandler(predicate func(LogInfo) bool, next Handler) Handler {
+	return &filterHandler{predicate: predicate, next: next}
+}
+
+type filterHandler struct {
+	predicate func(LogInfo) bool
+	next      Handler
+}
+
+func (h *filterHandler) Handle(li LogInfo) error {
+	if !h.predicate(li) {
+		return nil
+	}
+	return h.next.Handle(li)
+}
+
+// AsyncHandler hands entries off to next from a single background goroutine
+// over a bounded channel, so that a slow sink (a file, a network call) never
+// blocks the caller of AddInfo/AddWarning/... Call Close to stop the
+// goroutine and wait for the channel to drain.
+type AsyncHandler struct {
+	next   Handler
+	ch     chan LogInfo
+	done   chan struct{}
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewAsyncHandler starts the background goroutine and returns the handler.
+// buffer is the channel capacity; once full, Handle drops the entry and
+// returns an error rather than blocking the producer.
+func NewAsyncHandler(next Handler, buffer int) *AsyncHandler {
+	h := &AsyncHandler{
+		next: next,
+		ch:   make(chan LogInfo, buffer),
+		done: make(chan struct{}),
+	}
+	go h.loop()
+	return h
+}
+
+func (h *AsyncHandler) loop() {
+	defer close(h.done)
+	for li := range h.ch {
+		_ = h.next.Handle(li)
+	}
+}
+
+// Handle enqueues li for the background goroutine. It does not block: a
+// full buffer results in a dropped entry and a non-nil error. Once Close has
+// been called, Handle returns an error instead of sending on the closed
+// channel
+func (h *AsyncHandler) Handle(li LogInfo) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return fmt.Errorf("log: async handler closed")
+	}
+	select {
+	case h.ch <- li:
+		return nil
+	default:
+		return fmt.Errorf("log: async handler buffer full, entry dropped")
+	}
+}
+
+// Close stops accepting new entries and blocks until the background
+// goroutine has drained the channel to next. Calling Close more than once is
+// a no-op
+func (h *AsyncHandler) Close() error {
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return nil
+	}
+	h.closed = true
+	close(h.ch)
+	h.mu.Unlock()
+
+	<-h.done
+	return nil
+}
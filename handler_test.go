@@ -0,0 +1,26 @@
+package log
+
+import "testing"
+
+// TestAsyncHandlerHandleAfterClose verifies a Handle call that races with or
+// follows Close returns an error instead of panicking on a send to a closed
+// channel
+func TestAsyncHandlerHandleAfterClose(t *testing.T) {
+	h := NewAsyncHandler(WriterHandler(discardWriter{}), 4)
+	if err := h.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if err := h.Handle(LogInfo{Type: Info, Message: "after close"}); err == nil {
+		t.Fatal("Handle after Close returned nil error, want an error")
+	}
+
+	// a second Close must also be safe
+	if err := h.Close(); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+}
+
+type discardWriter struct{}
+
+func (discardWriter) Write(p []byte) (int, error) { return len(p), nil }
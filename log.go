@@ -8,9 +8,12 @@
 package log
 
 import (
+	"encoding/json"
 	"fmt"
 	"runtime"
 	"strings"
+	"sync"
+	"time"
 )
 
 // LogType
@@ -28,26 +31,151 @@ const (
 
 const DelimMsgType string = `: `
 
+// severity gives each LogType a low-to-high ordering: App < Info < Success <
+// Warn < Error < Fatal. Used by Severity, Log.SetThreshold and Prevailing
+var severity = map[LogType]int{
+	App:     0,
+	Info:    1,
+	Success: 2,
+	Warn:    3,
+	Error:   4,
+	Fatal:   5,
+}
+
+// Severity returns t's rank in the App < Info < Success < Warn < Error <
+// Fatal ordering, lowest first. Unrecognized LogTypes rank as App (0)
+func (t LogType) Severity() int {
+	return severity[t]
+}
+
 type Log struct {
-	Prefix  string // Prefix
-	ln      []LogInfo
-	osIsWin bool
+	Prefix    string // Prefix
+	ln        []LogInfo
+	sharedLn  *[]LogInfo // non-nil once WithSharedBuffer(true) is in effect: ln is ignored, this is used instead
+	parentLn  *[]LogInfo // the buffer of the logger this one was derived from via With, for a later WithSharedBuffer(true)
+	osIsWin   bool
+	formatter Formatter
+	handlers  *[]handlerEntry // pointer so With-derived loggers share registrations with their parent
+	threshold LogType
+	fields    map[string]any
+	mu        *sync.Mutex // pointer so a logger and every logger derived from it via With share one lock
+}
+
+// handlerEntry pairs a registered Handler with the minimum level it accepts
+type handlerEntry struct {
+	h   Handler
+	min LogType
 }
 
 type LogInfo struct {
 	Type    LogType
 	Prefix  string
 	Message string
+	Fields  map[string]any
+	Time    time.Time
+	File    string
+	Line    int
+}
+
+// Formatter renders a LogInfo entry to its final string form. Implementations
+// must not rely on the OS-specific line ending applied by Log.ToString.
+type Formatter interface {
+	Format(LogInfo) string
+}
+
+// Built-in formatters. TEXT reproduces the legacy human-readable layout used
+// by LogInfo.ToString; JSON emits one JSON object per entry for log shippers.
+var (
+	TEXT Formatter = textFormatter{}
+	JSON Formatter = jsonFormatter{}
+)
+
+type textFormatter struct{}
+
+func (textFormatter) Format(lni LogInfo) string {
+	return lni.ToString()
+}
+
+type jsonFormatter struct{}
+
+func (jsonFormatter) Format(lni LogInfo) string {
+	b, err := json.Marshal(struct {
+		Time    time.Time      `json:"time"`
+		Type    LogType        `json:"type"`
+		Prefix  string         `json:"prefix,omitempty"`
+		Message string         `json:"message"`
+		File    string         `json:"file,omitempty"`
+		Line    int            `json:"line,omitempty"`
+		Fields  map[string]any `json:"fields,omitempty"`
+	}{
+		Time:    lni.Time,
+		Type:    lni.Type,
+		Prefix:  lni.Prefix,
+		Message: lni.Message,
+		File:    lni.File,
+		Line:    lni.Line,
+		Fields:  lni.Fields,
+	})
+	if err != nil {
+		return lni.ToString()
+	}
+	return string(b)
 }
 
 func NewLog(prefix string) *Log {
+	handlers := make([]handlerEntry, 0)
 	return &Log{
-		Prefix:  prefix,
-		ln:      make([]LogInfo, 0),
-		osIsWin: runtime.GOOS == "windows",
+		Prefix:    prefix,
+		ln:        make([]LogInfo, 0),
+		osIsWin:   runtime.GOOS == "windows",
+		formatter: TEXT,
+		handlers:  &handlers,
+		mu:        &sync.Mutex{},
 	}
 }
 
+// buf returns the slice this logger actually reads and appends to: its own
+// ln, or the parent's if it was created via WithSharedBuffer(true)
+func (r *Log) buf() *[]LogInfo {
+	if r.sharedLn != nil {
+		return r.sharedLn
+	}
+	return &r.ln
+}
+
+// SetFormatter changes the formatter used by ToString. It defaults to TEXT.
+func (r *Log) SetFormatter(f Formatter) {
+	r.formatter = f
+}
+
+// AddHandler registers a Handler that receives every entry added from this
+// point on whose level is at or above minLevel, in addition to it being kept
+// in the in-memory buffer returned by Notes(). Handler errors are ignored;
+// wrap a handler with FilterHandler or your own error handling if needed.
+func (r *Log) AddHandler(h Handler, minLevel LogType) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	*r.handlers = append(*r.handlers, handlerEntry{h: h, min: minLevel})
+}
+
+// dispatch writes an entry through every registered handler whose threshold
+// it meets
+func (r *Log) dispatch(li LogInfo) {
+	for _, he := range *r.handlers {
+		if li.Type.Severity() < he.min.Severity() {
+			continue
+		}
+		_ = he.h.Handle(li)
+	}
+}
+
+// SetThreshold drops entries below minLevel at add-time: they are neither
+// kept in the in-memory buffer nor dispatched to handlers. It defaults to
+// App, i.e. every entry is kept
+func (r *Log) SetThreshold(minLevel LogType) {
+	r.threshold = minLevel
+}
+
 // Fmt accepts format and argument to return a string
 func Fmt(format string, args ...any) string {
 	return fmt.Sprintf(format, args...)
@@ -56,52 +184,97 @@ func Fmt(format string, args ...any) string {
 // AddInfo adds an information message
 func (r *Log) AddInfo(msg ...string) {
 	for _, m := range msg {
-		addMessage(&r.ln, r.Prefix, m, Info)
+		r.add(m, Info, nil)
 	}
 }
 
 // AddWarning adds a warning message
 func (r *Log) AddWarning(msg ...string) {
 	for _, m := range msg {
-		addMessage(&r.ln, r.Prefix, m, Warn)
+		r.add(m, Warn, nil)
 	}
 }
 
 // AddError adds an error message
 func (r *Log) AddError(msg ...string) {
 	for _, m := range msg {
-		addMessage(&r.ln, r.Prefix, m, Error)
+		r.add(m, Error, nil)
 	}
 }
 
 // AddSuccess adds a success message
 func (r *Log) AddSuccess(msg ...string) {
 	for _, m := range msg {
-		addMessage(&r.ln, r.Prefix, m, Success)
+		r.add(m, Success, nil)
 	}
 }
 
 // AddAppMsg adds an application message
 func (r *Log) AddAppMsg(msg ...string) {
 	for _, m := range msg {
-		addMessage(&r.ln, r.Prefix, m, App)
+		r.add(m, App, nil)
 	}
 }
 
+// AddInfoKV adds an information message with structured key/value context,
+// e.g. AddInfoKV("user signed in", "user", name, "attempt", n)
+func (r *Log) AddInfoKV(msg string, kv ...any) {
+	r.add(msg, Info, fieldsFromKV(kv))
+}
+
+// AddWarningKV adds a warning message with structured key/value context
+func (r *Log) AddWarningKV(msg string, kv ...any) {
+	r.add(msg, Warn, fieldsFromKV(kv))
+}
+
+// AddErrorKV adds an error message with structured key/value context
+func (r *Log) AddErrorKV(msg string, kv ...any) {
+	r.add(msg, Error, fieldsFromKV(kv))
+}
+
+// AddSuccessKV adds a success message with structured key/value context
+func (r *Log) AddSuccessKV(msg string, kv ...any) {
+	r.add(msg, Success, fieldsFromKV(kv))
+}
+
+// AddAppMsgKV adds an application message with structured key/value context
+func (r *Log) AddAppMsgKV(msg string, kv ...any) {
+	r.add(msg, App, fieldsFromKV(kv))
+}
+
 // Append adds a note object or more to the current list
 func (r *Log) Append(ln ...LogInfo) {
-	r.ln = append(r.ln, ln...)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p := r.buf()
+	*p = append(*p, ln...)
 }
 
 // Clear live notes
 func (r *Log) Clear() {
-	r.ln = []LogInfo{}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	*r.buf() = []LogInfo{}
+}
+
+// HasErrors checks if the message array has errors or fatals
+func (r *Log) HasErrors() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, ln := range *r.buf() {
+		if ln.Type == Error || ln.Type == Fatal {
+			return true
+		}
+	}
+	return false
 }
 
-// HasErrors checks if the message array has errors
-func (r Log) HasErrors() bool {
-	for _, ln := range r.ln {
-		if ln.Type == Error {
+// HasFatals checks if the message array has fatal messages
+func (r *Log) HasFatals() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, ln := range *r.buf() {
+		if ln.Type == Fatal {
 			return true
 		}
 	}
@@ -109,8 +282,10 @@ func (r Log) HasErrors() bool {
 }
 
 // HasWarnings checks if the message array has warnings
-func (r Log) HasWarnings() bool {
-	for _, ln := range r.ln {
+func (r *Log) HasWarnings() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, ln := range *r.buf() {
 		if ln.Type == Warn {
 			return true
 		}
@@ -119,8 +294,10 @@ func (r Log) HasWarnings() bool {
 }
 
 // HasInfos checks if the message array has information messages
-func (r Log) HasInfos() bool {
-	for _, ln := range r.ln {
+func (r *Log) HasInfos() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, ln := range *r.buf() {
 		if ln.Type == Info {
 			return true
 		}
@@ -129,8 +306,10 @@ func (r Log) HasInfos() bool {
 }
 
 // HasSuccess checks if the message array has success messages
-func (r Log) HasSucceses() bool {
-	for _, ln := range r.ln {
+func (r *Log) HasSucceses() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, ln := range *r.buf() {
 		if ln.Type == Success {
 			return true
 		}
@@ -138,25 +317,54 @@ func (r Log) HasSucceses() bool {
 	return false
 }
 
-// Prevailing checks for a dominant message
+// Prevailing returns the highest-severity LogType present in the message
+// array (App < Info < Success < Warn < Error < Fatal), e.g. a log with 10
+// infos and 1 fatal is Fatal. Returns App if the array is empty
 func (r *Log) Prevailing() LogType {
-	return getDominantNoteType(&r.ln)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	top := App
+	for _, ln := range *r.buf() {
+		if ln.Type.Severity() > top.Severity() {
+			top = ln.Type
+		}
+	}
+	return top
+}
+
+// DominantByCount returns the LogType with the most occurrences among the
+// Info, Warn, Error and Success entries in the message array, the
+// pre-Prevailing behavior. Fatal and App entries are not counted. Ties and
+// an empty array resolve to App
+func (r *Log) DominantByCount() LogType {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return getDominantNoteType(r.buf())
 }
 
 // Notes will list all notes
 func (r *Log) Notes() []LogInfo {
-	return r.ln
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return *r.buf()
 }
 
-// ToString return the messages as a carriage/return delimited string
+// ToString return the messages as a carriage/return delimited string,
+// rendered through the log's configured Formatter (TEXT by default)
 func (r *Log) ToString() string {
 	lf := "\n"
 	if r.osIsWin {
 		lf = "\r\n"
 	}
+	f := r.formatter
+	if f == nil {
+		f = TEXT
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	sb := strings.Builder{}
-	for _, v := range r.ln {
-		sb.Write([]byte(v.ToString() + lf))
+	for _, v := range *r.buf() {
+		sb.Write([]byte(f.Format(v) + lf))
 	}
 	return sb.String()
 }
@@ -175,14 +383,76 @@ func (lni *LogInfo) ToString() string {
 	return td
 }
 
-// add new message to the message array
-func addMessage(nt *[]LogInfo, prefix, msg string, typ LogType) {
+// add builds a LogInfo, appends it to the in-memory buffer and dispatches it
+// to every registered handler, unless typ is below the configured threshold
+func (r *Log) add(msg string, typ LogType, fields map[string]any) {
+	if typ.Severity() < r.threshold.Severity() {
+		return
+	}
 	msg = strings.TrimSpace(msg)
-	*nt = append(*nt, LogInfo{
-		Prefix:  prefix,
+	file, line := callerLoc()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	li := LogInfo{
+		Prefix:  r.Prefix,
 		Message: msg,
 		Type:    typ,
-	})
+		Fields:  mergeFields(r.fields, fields),
+		Time:    time.Now(),
+		File:    file,
+		Line:    line,
+	}
+	p := r.buf()
+	*p = append(*p, li)
+	r.dispatch(li)
+}
+
+// mergeFields combines a logger's inherited context fields (set via With)
+// with the fields passed to a single *KV call, the latter taking precedence.
+// Returns nil if both are empty, so non-KV call sites are unaffected.
+func mergeFields(base, extra map[string]any) map[string]any {
+	if len(base) == 0 {
+		return extra
+	}
+	merged := make(map[string]any, len(base)+len(extra))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range extra {
+		merged[k] = v
+	}
+	return merged
+}
+
+// fieldsFromKV builds a Fields map from alternating key/value pairs, as used
+// by log15/logrus/zap style call sites. A trailing key without a value is
+// recorded with a nil value.
+func fieldsFromKV(kv []any) map[string]any {
+	if len(kv) == 0 {
+		return nil
+	}
+	fields := make(map[string]any, (len(kv)+1)/2)
+	for i := 0; i < len(kv); i += 2 {
+		key := fmt.Sprintf("%v", kv[i])
+		if i+1 < len(kv) {
+			fields[key] = kv[i+1]
+		} else {
+			fields[key] = nil
+		}
+	}
+	return fields
+}
+
+// callerLoc reports the file:line of the call site that ultimately invoked
+// one of the Log.Add* methods, skipping over the Add* method and this
+// package's own add* helper.
+func callerLoc() (string, int) {
+	_, file, line, ok := runtime.Caller(3)
+	if !ok {
+		return "", 0
+	}
+	return file, line
 }
 
 // get dominant message
@@ -0,0 +1,76 @@
+package log
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+// TestWithSharedBuffer verifies that a field-scoped child created via With
+// and then opted into WithSharedBuffer(true) shares its buffer with the
+// parent in both directions.
+func TestWithSharedBuffer(t *testing.T) {
+	parent := NewLog("svc")
+	parent.AddInfo("from parent")
+
+	child := parent.With("request_id", "123").WithSharedBuffer(true)
+	child.AddInfo("from child")
+
+	if !containsMessage(parent.Notes(), "from child") {
+		t.Fatalf("parent.Notes() does not contain the child's entry: %+v", parent.Notes())
+	}
+	if !containsMessage(child.Notes(), "from parent") {
+		t.Fatalf("child.Notes() does not contain the parent's entry: %+v", child.Notes())
+	}
+}
+
+// TestWithIsolatedByDefault verifies a With child's buffer stays isolated
+// from the parent unless WithSharedBuffer(true) is requested.
+func TestWithIsolatedByDefault(t *testing.T) {
+	parent := NewLog("svc")
+	child := parent.With("request_id", "123")
+	child.AddInfo("from child")
+
+	if containsMessage(parent.Notes(), "from child") {
+		t.Fatalf("parent.Notes() unexpectedly contains the child's entry: %+v", parent.Notes())
+	}
+}
+
+// TestConcurrentWithAddInfoKV exercises the HTTP-middleware/RPC pattern the
+// requests describe: many goroutines each pulling a request-scoped, buffer-
+// sharing logger from a context and logging through it concurrently, plus a
+// concurrent AddHandler. Run with -race.
+func TestConcurrentWithAddInfoKV(t *testing.T) {
+	base := NewLog("svc")
+	ctx := NewContext(context.Background(), base)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		base.AddHandler(FilterHandler(func(LogInfo) bool { return false }, WriterHandler(discardWriter{})), Info)
+	}()
+
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			l := FromContext(ctx).With("request_id", i).WithSharedBuffer(true)
+			l.AddInfoKV("handled request", "attempt", i)
+		}(i)
+	}
+	wg.Wait()
+
+	if len(base.Notes()) != 50 {
+		t.Fatalf("base.Notes() has %d entries, want 50", len(base.Notes()))
+	}
+}
+
+func containsMessage(notes []LogInfo, msg string) bool {
+	for _, n := range notes {
+		if n.Message == msg {
+			return true
+		}
+	}
+	return false
+}
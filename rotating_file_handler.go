@@ -0,0 +1,284 @@
+package log
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RotatingFilePolicy controls when and how a RotatingFileHandler rotates its
+// active file. The zero value disables both size- and age-based rotation,
+// so the handler behaves as a plain append-only file sink.
+type RotatingFilePolicy struct {
+	MaxSizeBytes int64         // rotate once the active file reaches this many bytes, 0 disables
+	MaxAge       time.Duration // rotate once the active file is this old, 0 disables
+	Pattern      string        // rotated segment filename, e.g. "app.%Y%m%d.log"; %Y %m %d %H %M %S are replaced. Empty defaults to "<path>.20060102150405"
+	MaxBackups   int           // prune rotated segments beyond this count, 0 keeps them all
+	Gzip         bool          // gzip rotated segments in the background
+}
+
+// RotatingFileHandler is a Handler that writes entries to a file at path,
+// rotating it by size and/or age according to Policy
+type RotatingFileHandler struct {
+	mu      sync.Mutex
+	path    string
+	policy  RotatingFilePolicy
+	fmt     Formatter
+	file    *os.File
+	written int64
+	opened  time.Time
+}
+
+// NewRotatingFileHandler opens (creating if necessary) the file at path and
+// returns a handler that writes formatted entries to it, rotating per policy
+func NewRotatingFileHandler(path string, policy RotatingFilePolicy) (*RotatingFileHandler, error) {
+	h := &RotatingFileHandler{
+		path:   path,
+		policy: policy,
+		fmt:    TEXT,
+	}
+	if err := h.open(); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// SetFormatter changes the formatter used to render entries. It defaults to TEXT
+func (h *RotatingFileHandler) SetFormatter(f Formatter) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.fmt = f
+}
+
+// Handle writes li to the active file, rotating first if the policy demands it
+func (h *RotatingFileHandler) Handle(li LogInfo) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.shouldRotate() {
+		if err := h.rotate(); err != nil {
+			return err
+		}
+	}
+
+	line := h.fmt.Format(li) + "\n"
+	n, err := io.WriteString(h.file, line)
+	h.written += int64(n)
+	return err
+}
+
+// Reopen closes and reopens the active file at the same path, picking up its
+// current size. Call this after an external tool (logrotate, a SIGHUP
+// handler) has moved the file out from under the process
+func (h *RotatingFileHandler) Reopen() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.file != nil {
+		h.file.Close()
+	}
+	return h.open()
+}
+
+// Close closes the active file
+func (h *RotatingFileHandler) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.file == nil {
+		return nil
+	}
+	return h.file.Close()
+}
+
+func (h *RotatingFileHandler) open() error {
+	f, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	h.file = f
+	h.written = info.Size()
+	h.opened = time.Now()
+	return nil
+}
+
+func (h *RotatingFileHandler) shouldRotate() bool {
+	if h.policy.MaxSizeBytes > 0 && h.written >= h.policy.MaxSizeBytes {
+		return true
+	}
+	if h.policy.MaxAge > 0 && time.Since(h.opened) >= h.policy.MaxAge {
+		return true
+	}
+	return false
+}
+
+// rotate closes the active file, renames it to the resolved backup name,
+// then reopens path. Gzip and backup pruning happen afterwards in the
+// background, in that order, so a rotation's pruning never runs against the
+// segment that same rotation is still compressing. Each rotation starts its
+// own goroutine; pruning after one rotation is not ordered against the gzip
+// of an earlier one
+func (h *RotatingFileHandler) rotate() error {
+	if h.file != nil {
+		h.file.Close()
+		h.file = nil
+	}
+
+	target := uniquify(h.resolveBackupName(time.Now()))
+	if err := os.Rename(h.path, target); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	go h.finishRotation(target)
+
+	return h.open()
+}
+
+// finishRotation gzips the just-rotated segment, if configured, then prunes
+// backups beyond MaxBackups. Run from a single goroutine per rotation so the
+// two never race over the same segment.
+func (h *RotatingFileHandler) finishRotation(target string) {
+	if h.policy.Gzip {
+		gzipAndRemove(target)
+	}
+	h.pruneBackups()
+}
+
+// uniquify appends ".1", ".2", ... to path until it names a file that
+// doesn't already exist, neither as is nor as its gzipped ".gz" form, so a
+// Pattern coarser than the rotation trigger (e.g. a daily Pattern with
+// size-based rotation) never clobbers an earlier backup instead of creating
+// a new one
+func uniquify(path string) string {
+	taken := func(p string) bool {
+		for _, c := range []string{p, p + ".gz"} {
+			if _, err := os.Stat(c); err == nil {
+				return true
+			}
+		}
+		return false
+	}
+	if !taken(path) {
+		return path
+	}
+	for i := 1; ; i++ {
+		candidate := fmt.Sprintf("%s.%d", path, i)
+		if !taken(candidate) {
+			return candidate
+		}
+	}
+}
+
+func (h *RotatingFileHandler) resolveBackupName(t time.Time) string {
+	pattern := h.policy.Pattern
+	if pattern == "" {
+		return h.path + "." + t.Format("20060102150405")
+	}
+	r := strings.NewReplacer(
+		"%Y", t.Format("2006"),
+		"%m", t.Format("01"),
+		"%d", t.Format("02"),
+		"%H", t.Format("15"),
+		"%M", t.Format("04"),
+		"%S", t.Format("05"),
+	)
+	dir := filepath.Dir(h.path)
+	return filepath.Join(dir, r.Replace(pattern))
+}
+
+// pruneBackups removes rotated segments beyond Policy.MaxBackups, oldest first
+func (h *RotatingFileHandler) pruneBackups() {
+	if h.policy.MaxBackups <= 0 {
+		return
+	}
+	dir := filepath.Dir(h.path)
+	re, err := backupNameRegex(h.path, h.policy.Pattern)
+	if err != nil {
+		return
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	var backups []os.FileInfo
+	for _, e := range entries {
+		if e.IsDir() || !re.MatchString(e.Name()) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, info)
+	}
+	if len(backups) <= h.policy.MaxBackups {
+		return
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].ModTime().After(backups[j].ModTime())
+	})
+	for _, info := range backups[h.policy.MaxBackups:] {
+		os.Remove(filepath.Join(dir, info.Name()))
+	}
+}
+
+// backupNameRegex builds a matcher for rotated segment filenames produced by
+// resolveBackupName (plus uniquify's optional ".N" disambiguator and Gzip's
+// optional ".gz" suffix) for the given path/pattern, so pruneBackups
+// recognizes its own backups regardless of what Pattern resolves to (it
+// otherwise shares no fixed prefix with the active file's base name)
+func backupNameRegex(path, pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return regexp.Compile(`^` + regexp.QuoteMeta(filepath.Base(path)) + `\.\d{14}(\.\d+)?(\.gz)?$`)
+	}
+	src := regexp.QuoteMeta(pattern)
+	for tok, repl := range map[string]string{
+		"%Y": `\d{4}`,
+		"%m": `\d{2}`,
+		"%d": `\d{2}`,
+		"%H": `\d{2}`,
+		"%M": `\d{2}`,
+		"%S": `\d{2}`,
+	} {
+		src = strings.ReplaceAll(src, tok, repl)
+	}
+	return regexp.Compile(`^` + src + `(\.\d+)?(\.gz)?$`)
+}
+
+// gzipAndRemove compresses path to path+".gz" and removes the original on success
+func gzipAndRemove(path string) {
+	src, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer src.Close()
+
+	dst, err := os.Create(path + ".gz")
+	if err != nil {
+		return
+	}
+	defer dst.Close()
+
+	gw := gzip.NewWriter(dst)
+	if _, err := io.Copy(gw, src); err != nil {
+		gw.Close()
+		return
+	}
+	if err := gw.Close(); err != nil {
+		return
+	}
+	src.Close()
+	os.Remove(path)
+}
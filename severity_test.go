@@ -0,0 +1,40 @@
+package log
+
+import "testing"
+
+// TestPrevailingHighestSeverity verifies Prevailing reports the highest
+// severity present, not the most frequent one
+func TestPrevailingHighestSeverity(t *testing.T) {
+	l := NewLog("svc")
+	for i := 0; i < 10; i++ {
+		l.AddInfo("ok")
+	}
+	l.AddError("oops")
+
+	if got := l.Prevailing(); got != Error {
+		t.Fatalf("Prevailing() = %v, want %v", got, Error)
+	}
+
+	l.AddInfo("one more fatal coming")
+	l.Append(LogInfo{Type: Fatal, Message: "boom"})
+
+	if got := l.Prevailing(); got != Fatal {
+		t.Fatalf("Prevailing() = %v, want %v", got, Fatal)
+	}
+	if got := l.DominantByCount(); got != Info {
+		t.Fatalf("DominantByCount() = %v, want %v (11 infos still outnumber everything else)", got, Info)
+	}
+}
+
+// TestHasErrorsIncludesFatals verifies HasErrors reports true for fatal-only logs
+func TestHasErrorsIncludesFatals(t *testing.T) {
+	l := NewLog("svc")
+	l.Append(LogInfo{Type: Fatal, Message: "boom"})
+
+	if !l.HasErrors() {
+		t.Fatal("HasErrors() = false, want true for a log containing only a fatal entry")
+	}
+	if !l.HasFatals() {
+		t.Fatal("HasFatals() = false, want true")
+	}
+}